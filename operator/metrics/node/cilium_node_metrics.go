@@ -11,12 +11,29 @@ import (
 	"github.com/cilium/cilium/pkg/metrics"
 	"github.com/cilium/cilium/pkg/metrics/metric"
 	"github.com/cilium/cilium/pkg/option"
+	"github.com/cilium/cilium/pkg/time"
 )
 
 var Cell = metrics.Metric(NewWorkqueuePrometheusMetricsProvider)
 
 const workqueueSubsystem = "workqueue"
 
+// nativeHistogramBucketFactor is the growth factor between adjacent native
+// histogram buckets. 1.1 gives roughly 10% relative resolution, which is
+// enough to tell apart latencies within the same decade, which this
+// package's classic buckets (one per power of ten) collapse into one bucket.
+const nativeHistogramBucketFactor = 1.1
+
+// nativeHistogramMaxBucketNumber bounds how many sparse buckets a single
+// native histogram series may grow to before Prometheus starts merging
+// adjacent buckets, to keep scrape payloads bounded under high cardinality.
+const nativeHistogramMaxBucketNumber = 100
+
+// nativeHistogramMinResetDuration is the minimum time a native histogram's
+// bucket schema is kept stable before it's allowed to reset and possibly
+// shrink resolution again, avoiding schema flapping under bursty load.
+const nativeHistogramMinResetDuration = time.Hour
+
 type WorkqueuePrometheusMetricsProvider struct {
 	Retries                 metric.Vec[metric.Counter]
 	Depth                   metric.Vec[metric.Gauge]
@@ -77,20 +94,20 @@ func NewWorkqueuePrometheusMetricsProvider() *WorkqueuePrometheusMetricsProvider
 			Name:      "adds_total",
 			Help:      "Total number of adds handled by the workqueue",
 		}, labels),
-		Latency: metric.NewHistogramVecWithLabels(metric.HistogramOpts{
+		Latency: metric.NewHistogramVecWithLabels(workqueueHistogramOpts(metric.HistogramOpts{
 			Namespace: namespace,
 			Subsystem: subsystem,
 			Name:      "latency",
 			Help:      "How long in seconds an item stays in workqueue before being requested",
 			Buckets:   prometheus.ExponentialBuckets(10e-9, 10, 10),
-		}, labels),
-		WorkDuration: metric.NewHistogramVecWithLabels(metric.HistogramOpts{
+		}), labels),
+		WorkDuration: metric.NewHistogramVecWithLabels(workqueueHistogramOpts(metric.HistogramOpts{
 			Namespace: namespace,
 			Subsystem: subsystem,
 			Name:      "work_duration",
 			Help:      "How long in seconds processing an item from workqueue takes",
 			Buckets:   prometheus.ExponentialBuckets(10e-9, 10, 10),
-		}, labels),
+		}), labels),
 		UnfinishedWorkSeconds: metric.NewGaugeVecWithLabels(metric.GaugeOpts{
 			Namespace: namespace,
 			Subsystem: subsystem,
@@ -110,6 +127,21 @@ func NewWorkqueuePrometheusMetricsProvider() *WorkqueuePrometheusMetricsProvider
 	}
 }
 
+// workqueueHistogramOpts keeps opts' classic buckets, for back-compat with
+// scrapers that don't understand native histograms, and, when
+// --metrics-native-histograms allows it, additionally configures opts to
+// emit a native (sparse, auto-bucketed) representation alongside them.
+func workqueueHistogramOpts(opts metric.HistogramOpts) metric.HistogramOpts {
+	if metrics.Config.NativeHistogramsMode() == metrics.NativeHistogramsOff {
+		return opts
+	}
+
+	opts.NativeHistogramBucketFactor = nativeHistogramBucketFactor
+	opts.NativeHistogramMaxBucketNumber = nativeHistogramMaxBucketNumber
+	opts.NativeHistogramMinResetDuration = nativeHistogramMinResetDuration
+	return opts
+}
+
 func (p WorkqueuePrometheusMetricsProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
 	return p.Retries.WithLabelValues(name)
 }
@@ -122,12 +154,19 @@ func (p WorkqueuePrometheusMetricsProvider) NewAddsMetric(name string) workqueue
 	return p.Adds.WithLabelValues(name)
 }
 
+// NewLatencyMetric and NewWorkDurationMetric wrap their Observer in an
+// ObserverWithContext (see exemplar.go) so that callers holding onto the
+// returned value can attach a trace_id/span_id exemplar via
+// ObserveWithContext when --metrics-exemplars is enabled. Exemplars are only
+// actually rendered on scrape if the /metrics handler serves this namespace
+// with EnableOpenMetrics: true; neither this package nor pkg/metrics sets up
+// that handler.
 func (p WorkqueuePrometheusMetricsProvider) NewLatencyMetric(name string) workqueue.HistogramMetric {
-	return p.Latency.WithLabelValues(name)
+	return newExemplarObserver(p.Latency.WithLabelValues(name))
 }
 
 func (p WorkqueuePrometheusMetricsProvider) NewWorkDurationMetric(name string) workqueue.HistogramMetric {
-	return p.WorkDuration.WithLabelValues(name)
+	return newExemplarObserver(p.WorkDuration.WithLabelValues(name))
 }
 
 func (p WorkqueuePrometheusMetricsProvider) NewUnfinishedWorkSecondsMetric(name string) workqueue.SettableGaugeMetric {
@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package node
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/cilium/cilium/pkg/metrics"
+	"github.com/cilium/cilium/pkg/metrics/metric"
+)
+
+// ObserverWithContext is a metric.Observer that can also attach a
+// Prometheus exemplar describing the trace ctx belongs to, when one is
+// present and the underlying series supports it.
+type ObserverWithContext interface {
+	metric.Observer
+	ObserveWithContext(ctx context.Context, v float64)
+}
+
+// exemplarObserver wraps a metric.Observer returned by a metric.Vec so that
+// callers who have a context.Context handy can tie their observation to the
+// trace it's part of.
+//
+// Observe (without a context) is what satisfies workqueue.HistogramMetric,
+// since client-go calls it directly with no way for us to pass a context in;
+// it deliberately does not attempt to find one via itemctx itself; it is
+// each call site's job to prefer ObserveWithContext when it has reached a
+// point in the workqueue item's lifecycle where itemctx has something to
+// Pop.
+type exemplarObserver struct {
+	metric.Observer
+}
+
+func newExemplarObserver(obs metric.Observer) ObserverWithContext {
+	return exemplarObserver{Observer: obs}
+}
+
+func (o exemplarObserver) ObserveWithContext(ctx context.Context, v float64) {
+	if !metrics.Config.ExemplarsEnabled() {
+		o.Observe(v)
+		return
+	}
+
+	spanCtx := trace.SpanContextFromContext(ctx)
+	eo, ok := o.Observer.(prometheus.ExemplarObserver)
+	if !ok || !spanCtx.IsValid() {
+		o.Observe(v)
+		return
+	}
+
+	eo.ObserveWithExemplar(v, prometheus.Labels{
+		"trace_id": spanCtx.TraceID().String(),
+		"span_id":  spanCtx.SpanID().String(),
+	})
+}
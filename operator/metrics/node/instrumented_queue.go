@@ -0,0 +1,150 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package node
+
+import (
+	"context"
+	"sync"
+
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/cilium/cilium/pkg/time"
+)
+
+// NewInstrumentedQueue returns a rate-limiting workqueue for name whose
+// latency and work-duration histograms carry a trace exemplar for items
+// added via AddWithContext (see itemctx.go), instead of the plain Observe
+// client-go would otherwise call with no way to pass a context through.
+//
+// It records latency/work-duration itself rather than letting client-go's
+// own per-item timing call through to p's Observers, so the underlying
+// queue is built with a MetricsProvider that delegates everything else to p
+// but no-ops Latency/WorkDuration, to avoid observing each twice. Only items
+// added via Add or AddWithContext get a latency observation: AddAfter and
+// AddRateLimited go straight to the underlying queue, so no latency is
+// recorded for the items they add (their work duration still is).
+func (p *WorkqueuePrometheusMetricsProvider) NewInstrumentedQueue(name string) workqueue.RateLimitingInterface {
+	q := &instrumentedQueue{
+		addedAt:      make(map[any]time.Time),
+		startedAt:    make(map[any]time.Time),
+		latency:      newExemplarObserver(p.Latency.WithLabelValues(name)),
+		workDuration: newExemplarObserver(p.WorkDuration.WithLabelValues(name)),
+	}
+	q.RateLimitingInterface = workqueue.NewRateLimitingQueueWithConfig(
+		workqueue.DefaultControllerRateLimiter(),
+		workqueue.RateLimitingQueueConfig{
+			Name:            name,
+			MetricsProvider: delegatingMetricsProvider{p: p},
+		},
+	)
+	return q
+}
+
+// instrumentedQueue wraps a workqueue.RateLimitingInterface to correlate
+// items with the context.Context they were added under (via the package-wide
+// itemctx registry), so their latency and work-duration observations can
+// carry a trace exemplar.
+type instrumentedQueue struct {
+	workqueue.RateLimitingInterface
+
+	mu        sync.Mutex
+	addedAt   map[any]time.Time
+	startedAt map[any]time.Time
+
+	latency      ObserverWithContext
+	workDuration ObserverWithContext
+}
+
+// AddWithContext records ctx for item, so the latency observation made once
+// it's retrieved (and the work-duration observation made once it's
+// finished) can attach a trace exemplar. Call sites without a context handy
+// can still use Add, which tracks the item under context.Background(), so
+// its observations are recorded without an exemplar.
+func (q *instrumentedQueue) AddWithContext(ctx context.Context, item any) {
+	itemctx.Track(item, ctx)
+	q.mu.Lock()
+	q.addedAt[item] = time.Now()
+	q.mu.Unlock()
+	q.RateLimitingInterface.Add(item)
+}
+
+func (q *instrumentedQueue) Add(item any) {
+	q.AddWithContext(context.Background(), item)
+}
+
+func (q *instrumentedQueue) Get() (item any, shutdown bool) {
+	item, shutdown = q.RateLimitingInterface.Get()
+	if shutdown {
+		return item, shutdown
+	}
+
+	ctx := itemctx.Pop(item)
+
+	q.mu.Lock()
+	addedAt, ok := q.addedAt[item]
+	delete(q.addedAt, item)
+	q.startedAt[item] = time.Now()
+	q.mu.Unlock()
+
+	if ok {
+		q.latency.ObserveWithContext(ctx, time.Since(addedAt).Seconds())
+	}
+	// re-track under the same context so Done can recover it for the
+	// work-duration observation.
+	itemctx.Track(item, ctx)
+
+	return item, false
+}
+
+func (q *instrumentedQueue) Done(item any) {
+	ctx := itemctx.Pop(item)
+
+	q.mu.Lock()
+	startedAt, ok := q.startedAt[item]
+	delete(q.startedAt, item)
+	q.mu.Unlock()
+
+	if ok {
+		q.workDuration.ObserveWithContext(ctx, time.Since(startedAt).Seconds())
+	}
+	q.RateLimitingInterface.Done(item)
+}
+
+// delegatingMetricsProvider forwards every workqueue.MetricsProvider method
+// to p, except the two instrumentedQueue records itself.
+type delegatingMetricsProvider struct {
+	p *WorkqueuePrometheusMetricsProvider
+}
+
+func (d delegatingMetricsProvider) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return d.p.NewDepthMetric(name)
+}
+
+func (d delegatingMetricsProvider) NewAddsMetric(name string) workqueue.CounterMetric {
+	return d.p.NewAddsMetric(name)
+}
+
+func (d delegatingMetricsProvider) NewLatencyMetric(name string) workqueue.HistogramMetric {
+	return noopHistogramMetric{}
+}
+
+func (d delegatingMetricsProvider) NewWorkDurationMetric(name string) workqueue.HistogramMetric {
+	return noopHistogramMetric{}
+}
+
+func (d delegatingMetricsProvider) NewUnfinishedWorkSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return d.p.NewUnfinishedWorkSecondsMetric(name)
+}
+
+func (d delegatingMetricsProvider) NewLongestRunningProcessorSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return d.p.NewLongestRunningProcessorSecondsMetric(name)
+}
+
+func (d delegatingMetricsProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return d.p.NewRetriesMetric(name)
+}
+
+type noopHistogramMetric struct{}
+
+func (noopHistogramMetric) Observe(float64) {}
@@ -0,0 +1,161 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package node
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/cilium/cilium/pkg/metrics"
+	"github.com/cilium/cilium/pkg/metrics/metric"
+)
+
+var instrumentedQueueTestSeq atomic.Int64
+
+// newTestProvider returns a WorkqueuePrometheusMetricsProvider backed by
+// real (uniquely named) histograms, so a test can Gather() them, but with
+// everything else no-op'd out to avoid registering metrics this test
+// doesn't care about.
+func newTestProvider() *WorkqueuePrometheusMetricsProvider {
+	seq := instrumentedQueueTestSeq.Add(1)
+	labels := metric.Labels{{Name: "queue_name", Values: metric.NewValues("test")}}
+	newOpts := func(name string) metric.HistogramOpts {
+		return metric.HistogramOpts{
+			Namespace: "test",
+			Subsystem: "instrumented_queue",
+			Name:      fmt.Sprintf("%s_%d", name, seq),
+			Help:      "test metric",
+			Buckets:   prometheus.DefBuckets,
+		}
+	}
+
+	return &WorkqueuePrometheusMetricsProvider{
+		Retries:                 metrics.NoOpCounterVec,
+		Depth:                   metrics.NoOpGaugeVec,
+		Adds:                    metrics.NoOpCounterVec,
+		UnfinishedWorkSeconds:   metrics.NoOpGaugeVec,
+		LongestRunningProcessor: metrics.NoOpGaugeVec,
+		Latency:                 metric.NewHistogramVecWithLabels(newOpts("latency"), labels),
+		WorkDuration:            metric.NewHistogramVecWithLabels(newOpts("work_duration"), labels),
+	}
+}
+
+func sampledContext() context.Context {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanID:     trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+		TraceFlags: trace.FlagsSampled,
+	})
+	return trace.ContextWithSpanContext(context.Background(), sc)
+}
+
+func familyHasExemplar(t *testing.T, name string) bool {
+	t.Helper()
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			for _, b := range m.GetHistogram().GetBucket() {
+				if b.GetExemplar() != nil {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// TestInstrumentedQueueAttachesExemplar confirms that an item added via
+// AddWithContext under a sampled span carries that context through Get
+// (latency) and Done (work-duration), and that each resulting observation
+// attaches a trace exemplar -- the whole point of itemctx.Track/Pop.
+func TestInstrumentedQueueAttachesExemplar(t *testing.T) {
+	orig := metrics.Config
+	metrics.Config.MetricsExemplars = true
+	defer func() { metrics.Config = orig }()
+
+	p := newTestProvider()
+	q := p.NewInstrumentedQueue(t.Name())
+	defer q.ShutDown()
+
+	iq, ok := q.(*instrumentedQueue)
+	if !ok {
+		t.Fatalf("NewInstrumentedQueue returned %T, want *instrumentedQueue", q)
+	}
+
+	iq.AddWithContext(sampledContext(), "item")
+
+	item, shutdown := q.Get()
+	if shutdown {
+		t.Fatal("unexpected shutdown")
+	}
+	if item != "item" {
+		t.Fatalf("Get() = %v, want %q", item, "item")
+	}
+	q.Done(item)
+
+	latencyName := fmt.Sprintf("test_instrumented_queue_latency_%d", instrumentedQueueTestSeq.Load())
+	workDurationName := fmt.Sprintf("test_instrumented_queue_work_duration_%d", instrumentedQueueTestSeq.Load())
+
+	if !familyHasExemplar(t, latencyName) {
+		t.Errorf("%s: no bucket carries an exemplar", latencyName)
+	}
+	if !familyHasExemplar(t, workDurationName) {
+		t.Errorf("%s: no bucket carries an exemplar", workDurationName)
+	}
+}
+
+// TestInstrumentedQueuePlainAddFallsBackToBackground confirms that an item
+// added via the plain Add (no context available) still records
+// latency/work-duration -- just without an exemplar, since Add tracks the
+// item under context.Background().
+func TestInstrumentedQueuePlainAddFallsBackToBackground(t *testing.T) {
+	orig := metrics.Config
+	metrics.Config.MetricsExemplars = true
+	defer func() { metrics.Config = orig }()
+
+	p := newTestProvider()
+	q := p.NewInstrumentedQueue(t.Name())
+	defer q.ShutDown()
+
+	q.Add("item")
+
+	item, shutdown := q.Get()
+	if shutdown {
+		t.Fatal("unexpected shutdown")
+	}
+	q.Done(item)
+
+	latencyName := fmt.Sprintf("test_instrumented_queue_latency_%d", instrumentedQueueTestSeq.Load())
+	if familyHasExemplar(t, latencyName) {
+		t.Errorf("%s: expected no exemplar for an item added without a context", latencyName)
+	}
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	var found *dto.MetricFamily
+	for _, f := range families {
+		if f.GetName() == latencyName {
+			found = f
+		}
+	}
+	if found == nil || found.GetMetric()[0].GetHistogram().GetSampleCount() == 0 {
+		t.Errorf("%s: expected a latency observation even without a context", latencyName)
+	}
+}
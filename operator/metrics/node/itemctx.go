@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package node
+
+import (
+	"context"
+	"sync"
+)
+
+// itemContextRegistry correlates a workqueue item with the context.Context
+// it was added under.
+//
+// client-go's workqueue.MetricsProvider only ever hands our Observers a
+// duration, never the item or a context (see (*workqueue.Type).Get and
+// .Done), so a trace started while handling an item can't be threaded
+// through to the Observe call that records how long it sat in the queue or
+// took to process. Instrumented call sites are expected to Track an item
+// when it's added to the queue and Pop it back out once it has been
+// retrieved (for the latency metric) or finished (for the work duration
+// metric), so that exemplar.go's exemplarObserver can look the trace back
+// up. instrumented_queue.go's instrumentedQueue is the one caller that does
+// this today.
+type itemContextRegistry struct {
+	contexts sync.Map // item (any) -> context.Context
+}
+
+// itemctx is shared by every queue this provider instruments; items are
+// assumed to be comparable and unique across queues, which holds for the
+// client-go workqueues Cilium uses (they key on resource names or object
+// references).
+var itemctx itemContextRegistry
+
+// Track records that item was added under ctx.
+func (r *itemContextRegistry) Track(item any, ctx context.Context) {
+	r.contexts.Store(item, ctx)
+}
+
+// Pop returns the context item was last Tracked under, removing it from the
+// registry, or context.Background() if item was never tracked (or was
+// already popped).
+func (r *itemContextRegistry) Pop(item any) context.Context {
+	v, ok := r.contexts.LoadAndDelete(item)
+	if !ok {
+		return context.Background()
+	}
+	return v.(context.Context)
+}
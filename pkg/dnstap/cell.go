@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package dnstap
+
+import (
+	"context"
+
+	"github.com/cilium/hive/cell"
+	"github.com/spf13/pflag"
+)
+
+// Cell provides a Sink for the FQDN proxy to emit dnstap records to, driven
+// by the --dnstap-* flags below. When dnstap is disabled, a noopSink is
+// provided so call sites never need to nil-check the dependency.
+var Cell = cell.Module(
+	"dnstap",
+	"dnstap DNS observability",
+
+	cell.Config(defaultConfig),
+	cell.Provide(newSink),
+)
+
+type Config struct {
+	// DnstapEnabled turns on dnstap emission for the FQDN proxy.
+	DnstapEnabled bool
+
+	// DnstapSocketPath, if set, streams records to a dnstap collector
+	// listening on this unix socket. Takes precedence over
+	// DnstapFilePath if both are set.
+	DnstapSocketPath string
+
+	// DnstapFilePath, if set (and DnstapSocketPath is not), appends
+	// records to this file, rolling it once it exceeds
+	// DnstapFileMaxSizeMB.
+	DnstapFilePath string
+
+	// DnstapFileMaxSizeMB is the size, in megabytes, at which
+	// DnstapFilePath is rolled. Zero disables rolling.
+	DnstapFileMaxSizeMB int
+}
+
+var defaultConfig = Config{
+	DnstapEnabled:       false,
+	DnstapFileMaxSizeMB: 100,
+}
+
+func (c Config) Flags(flags *pflag.FlagSet) {
+	flags.Bool("dnstap-enabled", c.DnstapEnabled, "Emit dnstap records for DNS L7 policy decisions")
+	flags.String("dnstap-socket-path", c.DnstapSocketPath, "Unix socket to stream dnstap records to (takes precedence over dnstap-file-path)")
+	flags.String("dnstap-file-path", c.DnstapFilePath, "File to append dnstap records to")
+	flags.Int("dnstap-file-max-size-mb", c.DnstapFileMaxSizeMB, "Size in MB at which dnstap-file-path is rolled; 0 disables rolling")
+}
+
+func newSink(cfg Config) Sink {
+	if !cfg.DnstapEnabled {
+		return noopSink{}
+	}
+	if cfg.DnstapSocketPath != "" {
+		return NewSocketSink(cfg.DnstapSocketPath)
+	}
+	if cfg.DnstapFilePath != "" {
+		return NewFileSink(cfg.DnstapFilePath, int64(cfg.DnstapFileMaxSizeMB)*1024*1024)
+	}
+	return noopSink{}
+}
+
+// noopSink is handed out when dnstap emission is disabled.
+type noopSink struct{}
+
+func (noopSink) Write(_ context.Context, _ Message) error { return nil }
+func (noopSink) Close() error                             { return nil }
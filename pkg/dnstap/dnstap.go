@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package dnstap
+
+import "time"
+
+// MessageType mirrors the Type field of a dnstap Message as defined by the
+// dnstap protobuf schema (https://dnstap.info/Protocol-Schema).
+type MessageType int32
+
+const (
+	// MessageTypeResolverQuery is emitted when the FQDN proxy forwards a
+	// query to the upstream resolver.
+	MessageTypeResolverQuery MessageType = 3
+	// MessageTypeResolverResponse is emitted when the FQDN proxy receives a
+	// response from the upstream resolver.
+	MessageTypeResolverResponse MessageType = 4
+	// MessageTypeClientQuery is emitted when the FQDN proxy receives a query
+	// from an endpoint.
+	MessageTypeClientQuery MessageType = 5
+	// MessageTypeClientResponse is emitted when the FQDN proxy forwards a
+	// response back to the endpoint.
+	MessageTypeClientResponse MessageType = 6
+)
+
+// SocketFamily mirrors dnstap's SocketFamily enum.
+type SocketFamily int32
+
+const (
+	SocketFamilyINET  SocketFamily = 1
+	SocketFamilyINET6 SocketFamily = 2
+)
+
+// SocketProtocol mirrors dnstap's SocketProtocol enum.
+type SocketProtocol int32
+
+const (
+	SocketProtocolUDP SocketProtocol = 1
+	SocketProtocolTCP SocketProtocol = 2
+)
+
+// Verdict records the L7 DNS policy decision Cilium made for a message, so
+// that it can be carried alongside the raw dnstap record without requiring
+// readers that don't care about it to understand Cilium-specific semantics.
+type Verdict int32
+
+const (
+	VerdictUnknown Verdict = iota
+	VerdictAllowed
+	VerdictDenied
+)
+
+// CiliumMetadata is carried in the Extra field of the Dnstap envelope (a
+// plain byte blob reserved by the dnstap schema for producer-specific data).
+// Tools that only understand stock dnstap can safely ignore it; Cilium's own
+// readers decode it to correlate a record back to the endpoint and policy
+// decision that produced it.
+type CiliumMetadata struct {
+	EndpointID uint64
+	Verdict    Verdict
+	// RuleLabel identifies the PortRuleDNS (MatchName/MatchPattern) that
+	// produced the verdict, if any.
+	RuleLabel string
+}
+
+// Message is the envelope handed to a Sink. It follows the shape of a
+// dnstap.Message plus the CiliumMetadata extra field; a Sink is responsible
+// for serializing it to the dnstap protobuf wire format before it is handed
+// to the transport (unix socket or file).
+type Message struct {
+	Type            MessageType
+	QueryTime       time.Time
+	ResponseTime    time.Time
+	QueryAddress    []byte
+	ResponseAddress []byte
+	QueryPort       uint32
+	ResponsePort    uint32
+	SocketFamily    SocketFamily
+	SocketProtocol  SocketProtocol
+	QueryMessage    []byte // raw DNS wire format
+	ResponseMessage []byte // raw DNS wire format
+	Extra           CiliumMetadata
+}
+
+// NewQuery builds the Message emitted when the FQDN proxy observes a query
+// from an endpoint, before it has been forwarded upstream.
+func NewQuery(endpointID uint64, queryAddress []byte, port uint32, family SocketFamily, protocol SocketProtocol, raw []byte) Message {
+	return Message{
+		Type:           MessageTypeClientQuery,
+		QueryTime:      time.Now(),
+		QueryAddress:   queryAddress,
+		QueryPort:      port,
+		SocketFamily:   family,
+		SocketProtocol: protocol,
+		QueryMessage:   raw,
+		Extra:          CiliumMetadata{EndpointID: endpointID},
+	}
+}
+
+// NewResponse builds the Message emitted once a response for a previously
+// observed query has passed (or failed) the configured L7 DNS policy.
+func NewResponse(endpointID uint64, verdict Verdict, ruleLabel string, responseAddress []byte, port uint32, family SocketFamily, protocol SocketProtocol, raw []byte) Message {
+	return Message{
+		Type:            MessageTypeClientResponse,
+		ResponseTime:    time.Now(),
+		ResponseAddress: responseAddress,
+		ResponsePort:    port,
+		SocketFamily:    family,
+		SocketProtocol:  protocol,
+		ResponseMessage: raw,
+		Extra: CiliumMetadata{
+			EndpointID: endpointID,
+			Verdict:    verdict,
+			RuleLabel:  ruleLabel,
+		},
+	}
+}
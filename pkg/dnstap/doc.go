@@ -0,0 +1,12 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+// Package dnstap emits dnstap-format (https://dnstap.info) records for the
+// DNS queries, responses and L7 policy verdicts observed by the FQDN proxy.
+//
+// Unlike Hubble flows, dnstap is a small, stable wire format already
+// understood by a wide range of off-the-shelf tooling (dnstap-utils,
+// golang-dnstap based collectors, BIND/Unbound/CoreDNS log shippers). This
+// package only produces that wire format and hands it to a Sink; it does not
+// attempt to replace or duplicate Hubble's own observability pipeline.
+package dnstap
@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package dnstap
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileSinkAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dnstap.sock.log")
+
+	sink := NewFileSink(path, 0)
+	defer sink.Close()
+
+	msg := NewQuery(1, []byte{127, 0, 0, 1}, 53, SocketFamilyINET, SocketProtocolUDP, []byte("query"))
+	if err := sink.Write(context.Background(), msg); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if info.Size() == 0 {
+		t.Fatal("expected non-empty dnstap file after a Write")
+	}
+}
+
+func TestFileSinkRolls(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dnstap.sock.log")
+
+	// smaller than a single frame, so the first Write already exceeds it
+	// and triggers a roll.
+	sink := NewFileSink(path, 1)
+	defer sink.Close()
+
+	msg := NewQuery(1, []byte{127, 0, 0, 1}, 53, SocketFamilyINET, SocketProtocolUDP, []byte("query"))
+	if err := sink.Write(context.Background(), msg); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	rolled, err := filepath.Glob(path + ".*")
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	if len(rolled) != 1 {
+		t.Fatalf("found %d rolled files, want 1 (%v)", len(rolled), rolled)
+	}
+
+	data, err := os.ReadFile(rolled[0])
+	if err != nil {
+		t.Fatalf("ReadFile(%s): %v", rolled[0], err)
+	}
+	if len(data) == 0 {
+		t.Fatal("rolled file is empty, want the START frame and the written message")
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected a fresh active file at %s after rolling: %v", path, err)
+	}
+}
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package dnstap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// This file implements enough of the Frame Streams framing
+// (https://github.com/farsightsec/fstrm) to carry dnstap payloads: a
+// sequence of length-prefixed data frames, optionally preceded by a
+// bidirectional control handshake. dnstap itself only defines the payload
+// that goes inside a data frame; the framing is content-type agnostic.
+
+const dnstapContentType = "protobuf:dnstap.Dnstap"
+
+// control frame escape: a zero-length data frame marks the start of a
+// control frame.
+const controlFrameEscape uint32 = 0
+
+type controlType uint32
+
+const (
+	controlAccept controlType = 0x01
+	controlStart  controlType = 0x02
+	controlStop   controlType = 0x03
+	controlReady  controlType = 0x04
+	controlFinish controlType = 0x05
+)
+
+const controlFieldContentType uint32 = 0x01
+
+// writeControlFrame writes a single Frame Streams control frame: the escape
+// sequence, the frame's length, the control type, and (for READY/ACCEPT/
+// START) a single Content Type field carrying dnstapContentType.
+func writeControlFrame(w io.Writer, typ controlType, withContentType bool) error {
+	var payload []byte
+	payload = binary.BigEndian.AppendUint32(payload, uint32(typ))
+	if withContentType {
+		payload = binary.BigEndian.AppendUint32(payload, controlFieldContentType)
+		payload = binary.BigEndian.AppendUint32(payload, uint32(len(dnstapContentType)))
+		payload = append(payload, dnstapContentType...)
+	}
+
+	var frame []byte
+	frame = binary.BigEndian.AppendUint32(frame, controlFrameEscape)
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(payload)))
+	frame = append(frame, payload...)
+
+	_, err := w.Write(frame)
+	return err
+}
+
+func readControlFrame(r io.Reader, want controlType) error {
+	var hdr [8]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return fmt.Errorf("reading control frame header: %w", err)
+	}
+	if escape := binary.BigEndian.Uint32(hdr[0:4]); escape != controlFrameEscape {
+		return fmt.Errorf("expected control frame escape, got data frame of length %d", escape)
+	}
+
+	length := binary.BigEndian.Uint32(hdr[4:8])
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return fmt.Errorf("reading control frame payload: %w", err)
+	}
+	if len(payload) < 4 {
+		return fmt.Errorf("control frame payload too short")
+	}
+
+	got := controlType(binary.BigEndian.Uint32(payload[0:4]))
+	if got != want {
+		return fmt.Errorf("expected control frame %d, got %d", want, got)
+	}
+	return nil
+}
+
+// handshake performs the bidirectional control handshake required before a
+// duplex Frame Streams transport (such as our unix socket sink) may start
+// sending data frames: READY -> ACCEPT -> START.
+func handshake(rw io.ReadWriter) error {
+	if err := writeControlFrame(rw, controlReady, true); err != nil {
+		return fmt.Errorf("sending READY: %w", err)
+	}
+	if err := readControlFrame(rw, controlAccept); err != nil {
+		return fmt.Errorf("waiting for ACCEPT: %w", err)
+	}
+	if err := writeControlFrame(rw, controlStart, true); err != nil {
+		return fmt.Errorf("sending START: %w", err)
+	}
+	return nil
+}
+
+// writeDataFrame writes a single Frame Streams data frame containing payload.
+func writeDataFrame(w io.Writer, payload []byte) error {
+	if len(payload) == 0 {
+		// a zero-length data frame is indistinguishable from the control
+		// frame escape sequence, and must never be written on its own.
+		return fmt.Errorf("refusing to write empty dnstap payload")
+	}
+
+	var hdr [4]byte
+	binary.BigEndian.PutUint32(hdr[:], uint32(len(payload)))
+	if _, err := w.Write(hdr[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package dnstap
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestDataFrameRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	payload := []byte("a dnstap data frame")
+
+	if err := writeDataFrame(&buf, payload); err != nil {
+		t.Fatalf("writeDataFrame: %v", err)
+	}
+
+	var hdr [4]byte
+	if _, err := io.ReadFull(&buf, hdr[:]); err != nil {
+		t.Fatalf("reading length header: %v", err)
+	}
+	length := uint32(hdr[0])<<24 | uint32(hdr[1])<<16 | uint32(hdr[2])<<8 | uint32(hdr[3])
+	if int(length) != len(payload) {
+		t.Fatalf("frame length = %d, want %d", length, len(payload))
+	}
+
+	got := make([]byte, length)
+	if _, err := io.ReadFull(&buf, got); err != nil {
+		t.Fatalf("reading payload: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("payload = %q, want %q", got, payload)
+	}
+}
+
+func TestWriteDataFrameRejectsEmptyPayload(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeDataFrame(&buf, nil); err == nil {
+		t.Fatal("writeDataFrame(nil): expected error, got nil")
+	}
+}
+
+func TestControlFrameRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeControlFrame(&buf, controlReady, true); err != nil {
+		t.Fatalf("writeControlFrame: %v", err)
+	}
+	if err := readControlFrame(&buf, controlReady); err != nil {
+		t.Fatalf("readControlFrame: %v", err)
+	}
+}
+
+func TestReadControlFrameRejectsMismatch(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeControlFrame(&buf, controlAccept, false); err != nil {
+		t.Fatalf("writeControlFrame: %v", err)
+	}
+	if err := readControlFrame(&buf, controlStart); err == nil {
+		t.Fatal("readControlFrame: expected error on control type mismatch, got nil")
+	}
+}
+
+func TestReadControlFrameRejectsDataFrame(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeDataFrame(&buf, []byte("not a control frame")); err != nil {
+		t.Fatalf("writeDataFrame: %v", err)
+	}
+	if err := readControlFrame(&buf, controlReady); err == nil {
+		t.Fatal("readControlFrame: expected error reading a data frame as a control frame, got nil")
+	}
+}
+
+// TestHandshake exercises the full bidirectional control handshake
+// (READY -> ACCEPT -> START) over a real connection, with the peer side
+// played by hand instead of SocketSink so the test only depends on
+// framestream.go.
+func TestHandshake(t *testing.T) {
+	client, peer := net.Pipe()
+	defer client.Close()
+	defer peer.Close()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- handshake(client)
+	}()
+
+	if err := readControlFrame(peer, controlReady); err != nil {
+		t.Fatalf("peer: reading READY: %v", err)
+	}
+	if err := writeControlFrame(peer, controlAccept, false); err != nil {
+		t.Fatalf("peer: writing ACCEPT: %v", err)
+	}
+	if err := readControlFrame(peer, controlStart); err != nil {
+		t.Fatalf("peer: reading START: %v", err)
+	}
+
+	if err := <-errc; err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+}
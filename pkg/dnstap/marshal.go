@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package dnstap
+
+// marshal encodes msg as a dnstap.Dnstap protobuf message
+// (https://dnstap.info/Protocol-Schema), so that the resulting bytes can be
+// framed and handed to any dnstap-compatible reader. It is a small,
+// hand-rolled encoder rather than a generated one: the dnstap schema is
+// tiny and stable, and this avoids pulling in a full protobuf toolchain and
+// a vendored .proto for a handful of scalar fields.
+func marshal(m Message) []byte {
+	var inner pbWriter
+	inner.varint(1, uint64(m.Type))
+	if m.SocketFamily != 0 {
+		inner.varint(2, uint64(m.SocketFamily))
+	}
+	if m.SocketProtocol != 0 {
+		inner.varint(3, uint64(m.SocketProtocol))
+	}
+	if len(m.QueryAddress) > 0 {
+		inner.bytes(4, m.QueryAddress)
+	}
+	if len(m.ResponseAddress) > 0 {
+		inner.bytes(5, m.ResponseAddress)
+	}
+	if m.QueryPort != 0 {
+		inner.varint(6, uint64(m.QueryPort))
+	}
+	if m.ResponsePort != 0 {
+		inner.varint(7, uint64(m.ResponsePort))
+	}
+	if !m.QueryTime.IsZero() {
+		inner.varint(8, uint64(m.QueryTime.Unix()))
+		inner.varint(9, uint64(m.QueryTime.Nanosecond()))
+	}
+	if len(m.QueryMessage) > 0 {
+		inner.bytes(10, m.QueryMessage)
+	}
+	if !m.ResponseTime.IsZero() {
+		inner.varint(12, uint64(m.ResponseTime.Unix()))
+		inner.varint(13, uint64(m.ResponseTime.Nanosecond()))
+	}
+	if len(m.ResponseMessage) > 0 {
+		inner.bytes(14, m.ResponseMessage)
+	}
+
+	// Dnstap envelope field numbers, per the dnstap.proto schema:
+	// identity=1, version=2, extra=3, message=14, type=15 (required).
+	var outer pbWriter
+	outer.message(14, inner.buf)
+	if extra := marshalExtra(m.Extra); len(extra) > 0 {
+		outer.bytes(3, extra)
+	}
+	outer.varint(15, 1) // Dnstap.Type: MESSAGE
+	return outer.buf
+}
+
+// marshalExtra encodes CiliumMetadata as a small, independently-decodable
+// protobuf message so consumers that don't understand Cilium's extensions
+// can simply ignore the Dnstap.extra bytes.
+func marshalExtra(m CiliumMetadata) []byte {
+	if m.EndpointID == 0 && m.Verdict == VerdictUnknown && m.RuleLabel == "" {
+		return nil
+	}
+
+	var w pbWriter
+	w.varint(1, m.EndpointID)
+	w.varint(2, uint64(m.Verdict))
+	if m.RuleLabel != "" {
+		w.bytes(3, []byte(m.RuleLabel))
+	}
+	return w.buf
+}
+
+// pbWriter appends protobuf wire-format fields to buf. It only implements
+// the subset of the format dnstap actually uses: varint and length-delimited
+// fields.
+type pbWriter struct {
+	buf []byte
+}
+
+func (w *pbWriter) tag(field int, wireType byte) {
+	w.uvarint(uint64(field)<<3 | uint64(wireType))
+}
+
+func (w *pbWriter) uvarint(v uint64) {
+	for v >= 0x80 {
+		w.buf = append(w.buf, byte(v)|0x80)
+		v >>= 7
+	}
+	w.buf = append(w.buf, byte(v))
+}
+
+func (w *pbWriter) varint(field int, v uint64) {
+	w.tag(field, 0)
+	w.uvarint(v)
+}
+
+func (w *pbWriter) bytes(field int, b []byte) {
+	w.tag(field, 2)
+	w.uvarint(uint64(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *pbWriter) message(field int, b []byte) {
+	w.bytes(field, b)
+}
@@ -0,0 +1,161 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package dnstap
+
+import (
+	"testing"
+)
+
+// pbField is one decoded protobuf wire-format field: its number, wire type,
+// and payload (the raw varint value for wire type 0, or the raw bytes for
+// wire type 2).
+type pbField struct {
+	number   int
+	wireType byte
+	varint   uint64
+	bytes    []byte
+}
+
+// decodeFields is a minimal protobuf wire-format reader, the decoding
+// counterpart to pbWriter, used to assert marshal produces the field
+// numbers and wire types a real dnstap reader expects -- without pulling in
+// a full protobuf library for a test.
+func decodeFields(t *testing.T, buf []byte) []pbField {
+	t.Helper()
+
+	var fields []pbField
+	for len(buf) > 0 {
+		tag, n := uvarint(buf)
+		if n == 0 {
+			t.Fatalf("truncated tag in %x", buf)
+		}
+		buf = buf[n:]
+
+		field := pbField{number: int(tag >> 3), wireType: byte(tag & 0x7)}
+		switch field.wireType {
+		case 0: // varint
+			v, n := uvarint(buf)
+			if n == 0 {
+				t.Fatalf("truncated varint in %x", buf)
+			}
+			field.varint = v
+			buf = buf[n:]
+		case 2: // length-delimited
+			length, n := uvarint(buf)
+			if n == 0 {
+				t.Fatalf("truncated length in %x", buf)
+			}
+			buf = buf[n:]
+			if uint64(len(buf)) < length {
+				t.Fatalf("length-delimited field %d claims %d bytes, only %d remain", field.number, length, len(buf))
+			}
+			field.bytes = buf[:length]
+			buf = buf[length:]
+		default:
+			t.Fatalf("unsupported wire type %d for field %d", field.wireType, field.number)
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func uvarint(buf []byte) (uint64, int) {
+	var v uint64
+	var shift uint
+	for i, b := range buf {
+		v |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return v, i + 1
+		}
+		shift += 7
+	}
+	return 0, 0
+}
+
+func fieldByNumber(fields []pbField, number int) (pbField, bool) {
+	for _, f := range fields {
+		if f.number == number {
+			return f, true
+		}
+	}
+	return pbField{}, false
+}
+
+// TestMarshalEnvelope asserts marshal's outer Dnstap envelope uses the real
+// dnstap.proto field numbers (identity=1, version=2, extra=3, message=14,
+// type=15) and wire types, since any spec-compliant reader rejects (or
+// silently drops) a message with these wrong.
+func TestMarshalEnvelope(t *testing.T) {
+	msg := NewQuery(42, []byte{10, 0, 0, 1}, 53, SocketFamilyINET, SocketProtocolUDP, []byte("raw query"))
+	msg.Extra.RuleLabel = "*.example.com"
+
+	fields := decodeFields(t, marshal(msg))
+
+	typ, ok := fieldByNumber(fields, 15)
+	if !ok {
+		t.Fatal("missing required field 15 (Dnstap.type)")
+	}
+	if typ.wireType != 0 {
+		t.Fatalf("field 15 (type) wire type = %d, want 0 (varint)", typ.wireType)
+	}
+	if typ.varint != 1 {
+		t.Fatalf("field 15 (type) = %d, want 1 (MESSAGE)", typ.varint)
+	}
+
+	message, ok := fieldByNumber(fields, 14)
+	if !ok {
+		t.Fatal("missing field 14 (Dnstap.message)")
+	}
+	if message.wireType != 2 {
+		t.Fatalf("field 14 (message) wire type = %d, want 2 (length-delimited)", message.wireType)
+	}
+
+	extra, ok := fieldByNumber(fields, 3)
+	if !ok {
+		t.Fatal("missing field 3 (Dnstap.extra)")
+	}
+	if extra.wireType != 2 {
+		t.Fatalf("field 3 (extra) wire type = %d, want 2 (length-delimited)", extra.wireType)
+	}
+
+	// field 4 is not part of the Dnstap envelope at all; a prior bug wrote
+	// CiliumMetadata there instead of field 3.
+	if _, ok := fieldByNumber(fields, 4); ok {
+		t.Error("unexpected field 4 in Dnstap envelope")
+	}
+
+	inner := decodeFields(t, message.bytes)
+	innerType, ok := fieldByNumber(inner, 1)
+	if !ok || innerType.varint != uint64(MessageTypeClientQuery) {
+		t.Fatalf("inner Message.type = %+v, want %d (MessageTypeClientQuery)", innerType, MessageTypeClientQuery)
+	}
+	queryMessage, ok := fieldByNumber(inner, 10)
+	if !ok || string(queryMessage.bytes) != "raw query" {
+		t.Fatalf("inner Message.query_message = %q, want %q", queryMessage.bytes, "raw query")
+	}
+}
+
+func TestMarshalExtra(t *testing.T) {
+	extra := marshalExtra(CiliumMetadata{EndpointID: 7, Verdict: VerdictDenied, RuleLabel: "example.com"})
+	fields := decodeFields(t, extra)
+
+	endpointID, ok := fieldByNumber(fields, 1)
+	if !ok || endpointID.varint != 7 {
+		t.Fatalf("CiliumMetadata.endpoint_id = %+v, want 7", endpointID)
+	}
+	verdict, ok := fieldByNumber(fields, 2)
+	if !ok || verdict.varint != uint64(VerdictDenied) {
+		t.Fatalf("CiliumMetadata.verdict = %+v, want %d", verdict, VerdictDenied)
+	}
+	ruleLabel, ok := fieldByNumber(fields, 3)
+	if !ok || string(ruleLabel.bytes) != "example.com" {
+		t.Fatalf("CiliumMetadata.rule_label = %q, want %q", ruleLabel.bytes, "example.com")
+	}
+}
+
+func TestMarshalExtraOmittedWhenEmpty(t *testing.T) {
+	if extra := marshalExtra(CiliumMetadata{}); extra != nil {
+		t.Fatalf("marshalExtra(zero value) = %x, want nil", extra)
+	}
+}
@@ -0,0 +1,24 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package dnstap
+
+import "context"
+
+// Sink accepts dnstap Messages and is responsible for framing and
+// delivering them to a transport. Implementations must be safe for
+// concurrent use, since the FQDN proxy may call Write from multiple
+// goroutines handling different endpoints.
+type Sink interface {
+	// Write frames and emits msg. Implementations should bound how long
+	// the caller can be blocked by ctx, but can only do so to the extent
+	// their transport supports it: SocketSink honors ctx's deadline on
+	// every write via SetWriteDeadline, while FileSink writes to a
+	// regular file, which the Go runtime never treats as blocking, so
+	// ctx only gates whether the write is attempted at all.
+	Write(ctx context.Context, msg Message) error
+
+	// Close flushes any buffered state and releases the underlying
+	// transport. It must be safe to call Close more than once.
+	Close() error
+}
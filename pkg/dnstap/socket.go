@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package dnstap
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// SocketSink is a Sink which streams dnstap Messages as Frame Streams data
+// frames over a unix domain socket, after completing the bidirectional
+// control handshake. This is the transport the upstream dnstap tooling
+// (dnstap-utils, golang-dnstap collectors) listens on; SocketSink dials it.
+type SocketSink struct {
+	path    string
+	dialer  net.Dialer
+	timeout time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewSocketSink returns a Sink which lazily dials path on the first Write,
+// and re-dials (and re-performs the handshake) if the connection is lost.
+func NewSocketSink(path string) *SocketSink {
+	return &SocketSink{
+		path:    path,
+		timeout: 5 * time.Second,
+	}
+}
+
+func (s *SocketSink) Write(ctx context.Context, msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		if err := s.connectLocked(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Bound the write itself, not just the initial dial: a peer that
+	// stops reading (but doesn't close the connection) would otherwise
+	// block this call, and every other endpoint's Write, indefinitely.
+	s.conn.SetWriteDeadline(writeDeadline(ctx, s.timeout))
+
+	if err := writeDataFrame(s.conn, marshal(msg)); err != nil {
+		// the peer may have gone away; drop the connection so the next
+		// Write re-dials and re-handshakes instead of spinning on a
+		// permanently broken pipe.
+		s.conn.Close()
+		s.conn = nil
+		return fmt.Errorf("writing dnstap frame to %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// writeDeadline derives the deadline for a single write from ctx: ctx's own
+// deadline if it has one, or now+timeout as a backstop so a caller that
+// passes a context without a deadline (e.g. context.Background()) still
+// can't block a Write on a stalled peer indefinitely.
+func writeDeadline(ctx context.Context, timeout time.Duration) time.Time {
+	if dl, ok := ctx.Deadline(); ok {
+		return dl
+	}
+	return time.Now().Add(timeout)
+}
+
+func (s *SocketSink) connectLocked(ctx context.Context) error {
+	dialCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	conn, err := s.dialer.DialContext(dialCtx, "unix", s.path)
+	if err != nil {
+		return fmt.Errorf("dialing dnstap socket %s: %w", s.path, err)
+	}
+
+	if err := handshake(conn); err != nil {
+		conn.Close()
+		return fmt.Errorf("dnstap handshake with %s: %w", s.path, err)
+	}
+
+	s.conn = conn
+	return nil
+}
+
+func (s *SocketSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+
+	// best-effort graceful shutdown: tell the peer we're done and wait for
+	// it to acknowledge with FINISH, but never block Close indefinitely on
+	// a peer that has already gone away.
+	s.conn.SetDeadline(time.Now().Add(time.Second))
+	if err := writeControlFrame(s.conn, controlStop, false); err == nil {
+		readControlFrame(s.conn, controlFinish)
+	}
+
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
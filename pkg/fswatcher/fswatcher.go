@@ -5,17 +5,21 @@ package fswatcher
 
 import (
 	"errors"
+	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"sync"
 	"testing"
 
+	"github.com/fsnotify/fsnotify"
+
 	"github.com/cilium/cilium/pkg/time"
 )
 
 const (
-	// how often tracked targets are checked for changes by default
+	// how often tracked targets are checked for changes by default, when
+	// using the Poll backend
 	defaultInterval = 5 * time.Second
 
 	// when fswatcher detects that it runs in a test, it will refresh things much faster
@@ -62,9 +66,34 @@ func (o Op) Has(h Op) bool { return o&h != 0 }
 // Has reports if this event has the given operation.
 func (e Event) Has(op Op) bool { return e.Op.Has(op) }
 
-// Watcher implements a file polling mechanism which can track non-existing
-// files and emit creation events for them. All files which are supposed to be
-// tracked need to passed to the New constructor.
+// Backend selects how the Watcher detects changes to tracked files.
+type Backend int
+
+const (
+	// Auto uses Inotify where possible, and silently falls back to Poll if
+	// the watch cannot be set up (for example because a tracked path lives
+	// on NFS or FUSE, where inotify events are unreliable or unsupported).
+	Auto Backend = iota
+
+	// Inotify watches the parent directory of every tracked path with
+	// fsnotify and only re-checks tracked paths when an event is seen
+	// there. New returns an error if this backend cannot be set up.
+	Inotify
+
+	// Poll re-`Lstat`s every tracked path on a fixed interval. This is the
+	// original fswatcher behavior, kept as a fallback for filesystems where
+	// inotify is unreliable.
+	Poll
+)
+
+// Watcher tracks a set of files for changes, and emits events when they are
+// created, written to, or removed. All files which are supposed to be
+// tracked need to passed to the New constructor; non-existing files are
+// tracked too and will emit a Create event once they appear.
+//
+// By default (Auto backend) changes are detected via inotify, falling back
+// to polling every tracked path if inotify cannot be used. WithBackend can
+// force one or the other.
 //
 // Special care has to be taken around symlinks. Support for symlink is
 // limited, but it supports the following cases in order to support
@@ -79,7 +108,12 @@ func (e Event) Has(op Op) bool { return e.Op.Has(op) }
 //
 // Most notably, if a tracked file is a symlink, any update of the symlink
 // itself does not emit an event. Only if the target of the symlink observes
-// an event is the symlink re-evaluated.
+// an event is the symlink re-evaluated. The Inotify backend re-resolves the
+// full symlink chain of every tracked path on each directory event, rather
+// than trying to infer which tracked path a particular fsnotify event
+// belongs to. It only watches the parent directories of the tracked paths
+// themselves, not those of symlink targets: a change to a target in another
+// directory is only seen once some event occurs in a watched directory.
 type Watcher struct {
 	// Events is used to signal changes to any of the tracked files. It is
 	// guaranteed that Event.Name will always match one of the file paths
@@ -92,10 +126,20 @@ type Watcher struct {
 
 	tracked map[string]state // tracking state
 
-	// control the interval at which the watcher checks for changes
+	backend Backend
+
+	// control the interval at which the watcher checks for changes, only
+	// used by the Poll backend
 	interval time.Duration
 	ticker   <-chan time.Time
 
+	// fsWatcher is non-nil when the Inotify backend is in use.
+	fsWatcher *fsnotify.Watcher
+	// debounce coalesces bursts of fsnotify events (e.g. the CHMOD+WRITE
+	// pairs that atomic symlink swaps produce) into a single re-check. Zero
+	// disables coalescing. Only used by the Inotify backend.
+	debounce time.Duration
+
 	// stop channel used to indicate shutdown
 	stop chan struct{}
 	wg   sync.WaitGroup
@@ -112,13 +156,30 @@ type state struct {
 // Option to configure the Watcher
 type Option func(*Watcher)
 
-// WithInterval sets the interval at which the Watcher checks for changes
+// WithInterval sets the interval at which the Watcher checks for changes.
+// Only takes effect when the Poll backend is used.
 func WithInterval(d time.Duration) Option {
 	return func(w *Watcher) {
 		w.interval = d
 	}
 }
 
+// WithBackend selects how the Watcher detects changes. Defaults to Auto.
+func WithBackend(b Backend) Option {
+	return func(w *Watcher) {
+		w.backend = b
+	}
+}
+
+// WithDebounce coalesces fsnotify events arriving within d of each other
+// into a single re-check, instead of re-checking tracked paths for every
+// single event. Only takes effect when the Inotify backend is used.
+func WithDebounce(d time.Duration) Option {
+	return func(w *Watcher) {
+		w.debounce = d
+	}
+}
+
 // New creates a new Watcher which watches all trackedFile paths (they do not
 // need to exist yet).
 func New(trackedFiles []string, options ...Option) (*Watcher, error) {
@@ -132,6 +193,7 @@ func New(trackedFiles []string, options ...Option) (*Watcher, error) {
 		Errors:   make(chan error),
 		stop:     make(chan struct{}),
 		interval: interval,
+		backend:  Auto,
 	}
 
 	for _, option := range options {
@@ -144,14 +206,56 @@ func New(trackedFiles []string, options ...Option) (*Watcher, error) {
 		tracked[f] = state{path: f}
 	}
 	w.tracked = tracked
-	w.ticker = time.Tick(w.interval)
+
+	if w.backend != Poll {
+		if err := w.setupInotify(); err != nil {
+			if w.backend == Inotify {
+				return nil, err
+			}
+			// Auto: inotify isn't available on this path (NFS, FUSE, ...);
+			// silently fall back to polling.
+			w.backend = Poll
+		}
+	}
 
 	w.wg.Add(1)
-	go w.loop()
+	if w.backend == Poll {
+		w.ticker = time.Tick(w.interval)
+		go w.loop()
+	} else {
+		go w.inotifyLoop()
+	}
 
 	return w, nil
 }
 
+// setupInotify creates the fsnotify watcher and adds the parent directory of
+// every tracked path to it. Watching the parent (rather than the path
+// itself) lets us see Create events for tracked paths which don't exist yet,
+// and lets us observe the atomic rename/symlink-swap pattern Kubernetes uses
+// to update ConfigMap/Secret volume mounts.
+func (w *Watcher) setupInotify() error {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("creating fsnotify watcher: %w", err)
+	}
+
+	dirs := make(map[string]struct{}, len(w.tracked))
+	for path := range w.tracked {
+		dirs[filepath.Dir(path)] = struct{}{}
+	}
+
+	for dir := range dirs {
+		if err := fsWatcher.Add(dir); err != nil {
+			fsWatcher.Close()
+			return fmt.Errorf("watching %s: %w", dir, err)
+		}
+	}
+
+	w.fsWatcher = fsWatcher
+	return nil
+}
+
 func (w *Watcher) Close() {
 	close(w.stop)
 	w.wg.Wait()
@@ -163,14 +267,80 @@ func (w *Watcher) loop() {
 	for {
 		select {
 		case <-w.ticker:
-			w.tick()
+			w.evaluate()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// inotifyLoop re-checks all tracked paths whenever fsnotify reports a change
+// in one of their parent directories. It deliberately does not try to match
+// an fsnotify event back to the tracked path(s) it might concern: evaluate
+// is cheap (a handful of Lstat/Readlink calls) and doing so would require
+// duplicating the symlink-chain resolution evaluate already does.
+func (w *Watcher) inotifyLoop() {
+	defer w.wg.Done()
+	defer w.fsWatcher.Close()
+
+	var debounceTimer *time.Timer
+	var debounceC <-chan time.Time
+	defer func() {
+		if debounceTimer != nil {
+			debounceTimer.Stop()
+		}
+	}()
+
+	// Unlike the ticker backend, nothing triggers an initial check on its
+	// own; run one immediately so already-existing tracked files emit their
+	// Create event right away rather than waiting for an unrelated fsnotify
+	// event to show up.
+	w.evaluate()
+
+	for {
+		select {
+		case _, ok := <-w.fsWatcher.Events:
+			if !ok {
+				return
+			}
+
+			if w.debounce <= 0 {
+				w.evaluate()
+				continue
+			}
+
+			if debounceTimer == nil {
+				debounceTimer = time.NewTimer(w.debounce)
+				debounceC = debounceTimer.C
+			} else {
+				if !debounceTimer.Stop() {
+					<-debounceTimer.C
+				}
+				debounceTimer.Reset(w.debounce)
+			}
+
+		case <-debounceC:
+			w.evaluate()
+			debounceTimer = nil
+			debounceC = nil
+
+		case err, ok := <-w.fsWatcher.Errors:
+			if !ok {
+				return
+			}
+			w.sendError(err)
+
 		case <-w.stop:
 			return
 		}
 	}
 }
 
-func (w *Watcher) tick() {
+// evaluate re-`Lstat`s every tracked path and emits events for any observed
+// changes. It is the shared core of both backends: the Poll backend calls it
+// on a fixed interval, the Inotify backend calls it whenever fsnotify
+// reports a change in a tracked path's parent directory.
+func (w *Watcher) evaluate() {
 	for _, oldState := range w.tracked {
 		path := oldState.path
 		oldInfo := oldState.info
@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package fswatcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// recvEvent waits up to a generous timeout for an Event matching want's Op
+// on want's Name, ignoring any other events seen in the meantime (the
+// Inotify backend re-evaluates every tracked path on every fsnotify event,
+// so unrelated Create/Write events for other tracked files can interleave).
+func recvEvent(t *testing.T, w *Watcher, name string, op Op) {
+	t.Helper()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case e := <-w.Events:
+			if e.Name == name && e.Has(op) {
+				return
+			}
+		case err := <-w.Errors:
+			t.Fatalf("unexpected error from watcher: %v", err)
+		case <-deadline:
+			t.Fatalf("timed out waiting for %v event on %s", op, name)
+		}
+	}
+}
+
+func TestInotifyCreateWriteRemove(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target")
+
+	w, err := New([]string{target}, WithBackend(Inotify))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer w.Close()
+
+	if err := os.WriteFile(target, []byte("hello"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	recvEvent(t, w, target, Create)
+
+	if err := os.WriteFile(target, []byte("hello world"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	recvEvent(t, w, target, Write)
+
+	if err := os.Remove(target); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	recvEvent(t, w, target, Remove)
+}
+
+func TestInotifySymlinkSwap(t *testing.T) {
+	dir := t.TempDir()
+	link := filepath.Join(dir, "link")
+	targetA := filepath.Join(dir, "a")
+	targetB := filepath.Join(dir, "b")
+
+	if err := os.WriteFile(targetA, []byte("a"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := os.WriteFile(targetB, []byte("bbb"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := os.Symlink(targetA, link); err != nil {
+		t.Fatalf("Symlink: %v", err)
+	}
+
+	w, err := New([]string{link}, WithBackend(Inotify))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer w.Close()
+
+	recvEvent(t, w, link, Create)
+
+	// Kubernetes-style atomic symlink swap: create a new symlink under a
+	// temporary name and rename it over the old one.
+	tmpLink := link + ".tmp"
+	if err := os.Symlink(targetB, tmpLink); err != nil {
+		t.Fatalf("Symlink: %v", err)
+	}
+	if err := os.Rename(tmpLink, link); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	recvEvent(t, w, link, Write)
+}
+
+// TestAutoFallsBackToPoll confirms that Auto falls back to polling *all*
+// tracked paths, not just the one fsnotify couldn't watch, whenever any
+// watched directory can't be set up (e.g. because it doesn't exist yet).
+// This is surprising enough behavior -- a single bad path silently
+// degrading every other tracked path to polling -- to be worth pinning
+// down with a regression test.
+func TestAutoFallsBackToPoll(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good")
+	bad := filepath.Join(dir, "missing-parent", "bad")
+
+	w, err := New([]string{good, bad}, WithBackend(Auto))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer w.Close()
+
+	if w.backend != Poll {
+		t.Fatalf("backend = %v, want Poll (fallback after fsWatcher.Add failure)", w.backend)
+	}
+
+	// Polling doesn't care that missing-parent/ didn't exist at watch
+	// setup time; it should still pick up both paths once they appear.
+	if err := os.WriteFile(good, []byte("hello"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	recvEvent(t, w, good, Create)
+
+	if err := os.MkdirAll(filepath.Dir(bad), 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(bad, []byte("hello"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	recvEvent(t, w, bad, Create)
+}
+
+func TestInotifyBackendRequiresWatchableDirs(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "missing-parent", "bad")
+
+	if _, err := New([]string{bad}, WithBackend(Inotify)); err == nil {
+		t.Fatal("New with Inotify backend: expected error, got nil")
+	}
+}
@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/cilium/hive/cell"
+	"github.com/spf13/pflag"
+)
+
+// CiliumOperatorNamespace is the Prometheus namespace every metric
+// registered by cilium-operator is expected to use.
+const CiliumOperatorNamespace = "cilium_operator"
+
+// Metric registers ctor's return value with hive's dependency injection, so
+// it can be constructed lazily (only once something actually depends on it)
+// the same way every other metrics provider in this repo is.
+func Metric[T any](ctor func() T) cell.Cell {
+	return cell.Provide(ctor)
+}
+
+// NativeHistogramsSetting controls whether histograms additionally collect
+// a native (sparse, auto-bucketed) representation alongside their classic
+// buckets.
+type NativeHistogramsSetting string
+
+const (
+	// NativeHistogramsAuto enables native histograms; it is a distinct
+	// setting from On so that a future Prometheus-capability probe can
+	// decide for itself without a user-visible config change.
+	NativeHistogramsAuto NativeHistogramsSetting = "auto"
+	// NativeHistogramsOn always enables native histograms.
+	NativeHistogramsOn NativeHistogramsSetting = "on"
+	// NativeHistogramsOff always disables native histograms; only the
+	// classic buckets are collected.
+	NativeHistogramsOff NativeHistogramsSetting = "off"
+)
+
+// config holds the --metrics-* flags that don't belong to any single
+// metric, but instead change how metrics are collected or exposed across
+// the board.
+type config struct {
+	// MetricsExemplars enables attaching a trace_id/span_id exemplar to
+	// histogram observations made via an ObserverWithContext, when the
+	// context carries a sampled span.
+	MetricsExemplars bool
+
+	// MetricsNativeHistograms is one of "auto", "on" or "off" (see
+	// NativeHistogramsSetting).
+	MetricsNativeHistograms string
+}
+
+var defaultConfig = config{
+	MetricsExemplars:        false,
+	MetricsNativeHistograms: string(NativeHistogramsAuto),
+}
+
+// Config is the metrics package's global configuration, populated from the
+// --metrics-exemplars and --metrics-native-histograms flags.
+var Config = defaultConfig
+
+// ExemplarsEnabled reports whether histogram/summary observers should
+// attach a trace exemplar when one is available.
+func (c config) ExemplarsEnabled() bool {
+	return c.MetricsExemplars
+}
+
+// NativeHistogramsMode reports the configured native histogram setting.
+func (c config) NativeHistogramsMode() NativeHistogramsSetting {
+	return NativeHistogramsSetting(c.MetricsNativeHistograms)
+}
+
+// BindFlags registers the --metrics-exemplars and --metrics-native-histograms
+// flags, binding them directly into the package-global Config. Unlike
+// pkg/datapath/loader's Config, which hive provides through cell.Config,
+// Config here is a bare singleton read by metric providers across
+// packages, so there's no DI constructor to hand the parsed flags to. It is called once, from the agent's and operator's root
+// commands, before Config is read by any metric provider.
+func BindFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&Config.MetricsExemplars, "metrics-exemplars", defaultConfig.MetricsExemplars,
+		"Attach a trace_id/span_id exemplar to histogram observations made via an ObserverWithContext, when the active span is sampled")
+	flags.StringVar(&Config.MetricsNativeHistograms, "metrics-native-histograms", defaultConfig.MetricsNativeHistograms,
+		fmt.Sprintf("Emit native Prometheus histograms alongside classic ones (%s, %s or %s)",
+			NativeHistogramsAuto, NativeHistogramsOn, NativeHistogramsOff))
+}
@@ -0,0 +1,179 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+// Package metric wraps the handful of Prometheus collector types Cilium
+// registers metrics with, so call sites depend on this package's narrower
+// interfaces instead of reaching into client_golang/prometheus directly.
+package metric
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Counter is a Prometheus counter.
+type Counter interface {
+	Inc()
+	Add(float64)
+}
+
+// Gauge is a Prometheus gauge.
+type Gauge interface {
+	Set(float64)
+	Inc()
+	Dec()
+	Add(float64)
+}
+
+// Observer is a Prometheus histogram or summary observation sink. It is
+// deliberately the minimal interface client-go's workqueue.HistogramMetric
+// also satisfies, so the Observers a metric.Vec[Observer] hands out can be
+// passed straight to code expecting that type.
+type Observer interface {
+	Observe(float64)
+}
+
+// Vec is a collection of metrics of type T, one per distinct combination of
+// label values.
+type Vec[T any] interface {
+	// WithLabelValues returns the metric for this combination of label
+	// values, creating it on first use.
+	WithLabelValues(values ...string) T
+}
+
+// LabelDescription describes one label of a Vec and the values it is
+// expected to take. The constructors in this package only use Name;
+// they do not pre-create a series for each of Values, so a series first
+// appears when WithLabelValues is called for it.
+type LabelDescription struct {
+	Name   string
+	Values []string
+}
+
+// Labels is the set of labels a Vec is keyed by, in the order
+// WithLabelValues expects its arguments.
+type Labels []LabelDescription
+
+// NewValues is a small helper for building a LabelDescription's Values.
+func NewValues(values ...string) []string {
+	return values
+}
+
+// CounterOpts configures a counter metric.
+type CounterOpts struct {
+	Namespace string
+	Subsystem string
+	Name      string
+	Help      string
+}
+
+// GaugeOpts configures a gauge metric.
+type GaugeOpts struct {
+	Namespace string
+	Subsystem string
+	Name      string
+	Help      string
+}
+
+// HistogramOpts configures a histogram metric.
+type HistogramOpts struct {
+	Namespace string
+	Subsystem string
+	Name      string
+	Help      string
+	Buckets   []float64
+
+	// NativeHistogramBucketFactor, when non-zero, additionally configures
+	// the classic histogram built from these Opts to also collect a
+	// native (sparse, auto-bucketed) histogram with this growth factor
+	// between adjacent buckets, per Prometheus' native histogram support.
+	// Scrapers that don't understand native histograms still see the
+	// classic buckets above; this only adds a second, denser
+	// representation alongside them.
+	NativeHistogramBucketFactor float64
+
+	// NativeHistogramMaxBucketNumber bounds how many sparse buckets a
+	// single native histogram series may grow to before Prometheus starts
+	// merging adjacent buckets, keeping scrape payloads bounded under
+	// high cardinality.
+	NativeHistogramMaxBucketNumber uint32
+
+	// NativeHistogramMinResetDuration is the minimum time a native
+	// histogram's bucket schema is kept stable before it may reset (and
+	// possibly shrink resolution again), avoiding schema flapping under
+	// bursty load.
+	NativeHistogramMinResetDuration time.Duration
+}
+
+func (o HistogramOpts) toPrometheus() prometheus.HistogramOpts {
+	return prometheus.HistogramOpts{
+		Namespace:                       o.Namespace,
+		Subsystem:                       o.Subsystem,
+		Name:                            o.Name,
+		Help:                            o.Help,
+		Buckets:                         o.Buckets,
+		NativeHistogramBucketFactor:     o.NativeHistogramBucketFactor,
+		NativeHistogramMaxBucketNumber:  o.NativeHistogramMaxBucketNumber,
+		NativeHistogramMinResetDuration: o.NativeHistogramMinResetDuration,
+	}
+}
+
+type counterVec struct{ *prometheus.CounterVec }
+
+func (v counterVec) WithLabelValues(values ...string) Counter {
+	return v.CounterVec.WithLabelValues(values...)
+}
+
+type gaugeVec struct{ *prometheus.GaugeVec }
+
+func (v gaugeVec) WithLabelValues(values ...string) Gauge {
+	return v.GaugeVec.WithLabelValues(values...)
+}
+
+type histogramVec struct{ *prometheus.HistogramVec }
+
+func (v histogramVec) WithLabelValues(values ...string) Observer {
+	return v.HistogramVec.WithLabelValues(values...)
+}
+
+func labelNames(labels Labels) []string {
+	names := make([]string, 0, len(labels))
+	for _, l := range labels {
+		names = append(names, l.Name)
+	}
+	return names
+}
+
+// NewCounterVecWithLabels creates and registers a labeled counter vector.
+func NewCounterVecWithLabels(opts CounterOpts, labels Labels) Vec[Counter] {
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: opts.Namespace,
+		Subsystem: opts.Subsystem,
+		Name:      opts.Name,
+		Help:      opts.Help,
+	}, labelNames(labels))
+	prometheus.MustRegister(vec)
+	return counterVec{vec}
+}
+
+// NewGaugeVecWithLabels creates and registers a labeled gauge vector.
+func NewGaugeVecWithLabels(opts GaugeOpts, labels Labels) Vec[Gauge] {
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: opts.Namespace,
+		Subsystem: opts.Subsystem,
+		Name:      opts.Name,
+		Help:      opts.Help,
+	}, labelNames(labels))
+	prometheus.MustRegister(vec)
+	return gaugeVec{vec}
+}
+
+// NewHistogramVecWithLabels creates and registers a labeled histogram
+// vector. When opts sets the Native* fields, every series in the vector
+// also collects a native histogram alongside its classic buckets.
+func NewHistogramVecWithLabels(opts HistogramOpts, labels Labels) Vec[Observer] {
+	vec := prometheus.NewHistogramVec(opts.toPrometheus(), labelNames(labels))
+	prometheus.MustRegister(vec)
+	return histogramVec{vec}
+}
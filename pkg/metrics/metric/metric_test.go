@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package metric
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// TestHistogramOptsDualScrape confirms that a histogram configured with
+// the Native* opts still exposes its classic (bucket/sum/count) series
+// alongside the native one, so scrapers that don't understand native
+// histograms keep working unchanged.
+func TestHistogramOptsDualScrape(t *testing.T) {
+	opts := HistogramOpts{
+		Namespace:                       "test",
+		Name:                            "dual_scrape_seconds",
+		Help:                            "histogram used to assert classic and native series coexist",
+		Buckets:                         []float64{0.01, 0.1, 1},
+		NativeHistogramBucketFactor:     1.1,
+		NativeHistogramMaxBucketNumber:  100,
+		NativeHistogramMinResetDuration: time.Hour,
+	}
+
+	vec := prometheus.NewHistogramVec(opts.toPrometheus(), nil)
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(vec)
+	vec.WithLabelValues().Observe(0.05)
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+
+	var family *dto.MetricFamily
+	for _, f := range families {
+		if f.GetName() == "test_dual_scrape_seconds" {
+			family = f
+			break
+		}
+	}
+	if family == nil {
+		t.Fatalf("metric family test_dual_scrape_seconds not found in %d families", len(families))
+	}
+	if family.GetType() != dto.MetricType_HISTOGRAM {
+		t.Fatalf("type = %v, want HISTOGRAM", family.GetType())
+	}
+
+	h := family.GetMetric()[0].GetHistogram()
+	if len(h.GetBucket()) == 0 {
+		t.Error("expected classic _bucket series, got none")
+	}
+	if h.GetSampleCount() == 0 {
+		t.Error("expected classic _count series to be non-zero")
+	}
+	if h.GetSampleSum() == 0 {
+		t.Error("expected classic _sum series to be non-zero")
+	}
+	if h.GetSchema() == 0 && len(h.GetPositiveSpan()) == 0 {
+		t.Error("expected a native histogram representation (non-zero Schema or PositiveSpan) alongside the classic one")
+	}
+}
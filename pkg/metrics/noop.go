@@ -0,0 +1,41 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package metrics
+
+import "github.com/cilium/cilium/pkg/metrics/metric"
+
+// noopVec[T] hands out the same no-op metric for every combination of label
+// values, so callers that are handed one (because the feature area they
+// instrument is disabled) never need to nil-check the metric itself.
+type noopVec[T any] struct{ metric T }
+
+func (v noopVec[T]) WithLabelValues(_ ...string) T { return v.metric }
+
+type noopCounter struct{}
+
+func (noopCounter) Inc()           {}
+func (noopCounter) Add(_ float64) {}
+
+type noopGauge struct{}
+
+func (noopGauge) Set(_ float64) {}
+func (noopGauge) Inc()          {}
+func (noopGauge) Dec()          {}
+func (noopGauge) Add(_ float64) {}
+
+type noopObserver struct{}
+
+func (noopObserver) Observe(_ float64) {}
+
+// NoOpCounterVec is handed out in place of a real counter vector when the
+// feature area it would instrument is disabled.
+var NoOpCounterVec metric.Vec[metric.Counter] = noopVec[metric.Counter]{noopCounter{}}
+
+// NoOpGaugeVec is handed out in place of a real gauge vector when the
+// feature area it would instrument is disabled.
+var NoOpGaugeVec metric.Vec[metric.Gauge] = noopVec[metric.Gauge]{noopGauge{}}
+
+// NoOpObserverVec is handed out in place of a real histogram/summary vector
+// when the feature area it would instrument is disabled.
+var NoOpObserverVec metric.Vec[metric.Observer] = noopVec[metric.Observer]{noopObserver{}}
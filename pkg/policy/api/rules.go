@@ -0,0 +1,38 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package api
+
+// PortRuleDNS is a list of allowed DNS lookups.
+type PortRuleDNS struct {
+	// MatchName matches literal DNS names. A trailing "." is automatically
+	// added when missing.
+	//
+	// +optional
+	MatchName string `json:"matchName,omitempty"`
+
+	// MatchPattern allows using wildcards to match DNS names. All wildcards
+	// are case insensitive. The wildcards are: "*" matches 0 or more valid
+	// DNS characters. A trailing "." is automatically added when missing.
+	//
+	// +optional
+	MatchPattern string `json:"matchPattern,omitempty"`
+
+	// Observability configures additional per-rule observability
+	// integrations for DNS lookups matched by this rule, on top of the
+	// Hubble DNS visibility Cilium always provides.
+	//
+	// +optional
+	Observability *DNSObservability `json:"observability,omitempty"`
+}
+
+// DNSObservability configures per-PortRuleDNS observability integrations.
+type DNSObservability struct {
+	// Dnstap, if true, emits a dnstap record (see pkg/dnstap) for every
+	// query, response and L7 policy verdict evaluated against this rule.
+	// Has no effect unless dnstap emission is also enabled for the agent
+	// via --dnstap-enabled.
+	//
+	// +optional
+	Dnstap bool `json:"dnstap,omitempty"`
+}